@@ -0,0 +1,180 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package aggregator collects per-validator phase votes into a single
+// BLS12-381 aggregate signature plus a bitmap of signer indices, in place
+// of the append-each-ECDSA-signature pattern core previously used to build
+// a QC.
+package aggregator
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	blst "github.com/ethereum/go-ethereum/crypto/bls"
+)
+
+var (
+	errAlreadyAggregated = errors.New("vote set already reached quorum and was aggregated")
+	errDuplicateVote     = errors.New("duplicate vote from validator index")
+	errIndexOutOfRange   = errors.New("validator index out of range of current valSet")
+)
+
+// QC is the aggregated quorum certificate an Aggregator emits once it has
+// collected enough votes for a given (view, digest) pair: a single BLS
+// aggregate signature plus a bitmap recording which validator indices
+// signed, instead of a list of individual ECDSA signatures.
+type QC struct {
+	ViewNumber   *big.Int
+	Digest       common.Hash
+	AggSig       []byte
+	SignerBitmap []byte
+}
+
+// voteSet accumulates votes for a single (view, digest) pair until quorum.
+type voteSet struct {
+	viewNumber *big.Int
+	digest     common.Hash
+	sigs       map[int][]byte // validator index -> signature
+	done       bool
+}
+
+// Aggregator collects PrepareVote/PreCommitVote/CommitVote signatures
+// keyed by (view, digest) and produces a QC once a quorum of votes for the
+// same pair has been seen.
+type Aggregator struct {
+	valSetSize int
+	quorum     int
+	sets       map[string]*voteSet
+}
+
+// New returns an Aggregator sized for a validator set of valSetSize, with
+// quorum votes required to emit a QC (typically 2f+1).
+func New(valSetSize, quorum int) *Aggregator {
+	return &Aggregator{
+		valSetSize: valSetSize,
+		quorum:     quorum,
+		sets:       make(map[string]*voteSet),
+	}
+}
+
+func setKey(viewNumber *big.Int, digest common.Hash) string {
+	return viewNumber.String() + ":" + digest.Hex()
+}
+
+// AddVote feeds one validator's signature over (viewNumber, digest) into
+// the aggregator. It returns a non-nil QC the first time quorum is
+// reached for that pair; subsequent votes for an already-aggregated pair
+// are rejected with errAlreadyAggregated.
+func (a *Aggregator) AddVote(viewNumber *big.Int, digest common.Hash, validatorIndex int, sig []byte) (*QC, error) {
+	if validatorIndex < 0 || validatorIndex >= a.valSetSize {
+		return nil, errIndexOutOfRange
+	}
+
+	key := setKey(viewNumber, digest)
+	set, ok := a.sets[key]
+	if !ok {
+		set = &voteSet{viewNumber: viewNumber, digest: digest, sigs: make(map[int][]byte)}
+		a.sets[key] = set
+	}
+	if set.done {
+		return nil, errAlreadyAggregated
+	}
+	if _, dup := set.sigs[validatorIndex]; dup {
+		return nil, errDuplicateVote
+	}
+	set.sigs[validatorIndex] = sig
+
+	if len(set.sigs) < a.quorum {
+		return nil, nil
+	}
+
+	qc, err := aggregate(set)
+	if err != nil {
+		return nil, err
+	}
+	set.done = true
+	return qc, nil
+}
+
+// aggregate combines every signature in set into one BLS aggregate and
+// builds the signer bitmap that lets a verifier reconstruct which public
+// keys to combine.
+func aggregate(set *voteSet) (*QC, error) {
+	bitmap := make([]byte, neededBitmapLen(set))
+	sigs := make([][]byte, 0, len(set.sigs))
+	for idx, sig := range set.sigs {
+		setBit(bitmap, idx)
+		sigs = append(sigs, sig)
+	}
+
+	aggSig, err := blst.AggregateSignatures(sigs)
+	if err != nil {
+		return nil, err
+	}
+
+	return &QC{
+		ViewNumber:   set.viewNumber,
+		Digest:       set.digest,
+		AggSig:       aggSig,
+		SignerBitmap: bitmap,
+	}, nil
+}
+
+func neededBitmapLen(set *voteSet) int {
+	max := 0
+	for idx := range set.sigs {
+		if idx+1 > max {
+			max = idx + 1
+		}
+	}
+	return (max + 7) / 8
+}
+
+func setBit(bitmap []byte, idx int) {
+	bitmap[idx/8] |= 1 << uint(idx%8)
+}
+
+func bitSet(bitmap []byte, idx int) bool {
+	if idx/8 >= len(bitmap) {
+		return false
+	}
+	return bitmap[idx/8]&(1<<uint(idx%8)) != 0
+}
+
+// Verify reconstructs the aggregate public key from qc.SignerBitmap against
+// pubKeys (indexed the same way as the valSet the QC was built from) and
+// performs a single pairing check against qc's aggregate signature.
+func Verify(qc *QC, pubKeys [][]byte, quorum int) error {
+	signerCount := 0
+	var included [][]byte
+	for idx, pk := range pubKeys {
+		if bitSet(qc.SignerBitmap, idx) {
+			included = append(included, pk)
+			signerCount++
+		}
+	}
+	if signerCount < quorum {
+		return errors.New("signer bitmap does not meet quorum")
+	}
+
+	aggPub, err := blst.AggregatePublicKeys(included)
+	if err != nil {
+		return err
+	}
+	return blst.VerifyAggregate(aggPub, qc.Digest.Bytes(), qc.AggSig)
+}
@@ -0,0 +1,90 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package aggregator
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestAggregatorAddVoteOutOfRange(t *testing.T) {
+	a := New(4, 3)
+	if _, err := a.AddVote(big.NewInt(1), common.Hash{}, 4, []byte("sig")); err != errIndexOutOfRange {
+		t.Fatalf("expected errIndexOutOfRange, got %v", err)
+	}
+	if _, err := a.AddVote(big.NewInt(1), common.Hash{}, -1, []byte("sig")); err != errIndexOutOfRange {
+		t.Fatalf("expected errIndexOutOfRange, got %v", err)
+	}
+}
+
+func TestAggregatorAddVoteDuplicate(t *testing.T) {
+	a := New(4, 3)
+	view, digest := big.NewInt(1), common.Hash{1}
+
+	if qc, err := a.AddVote(view, digest, 0, []byte("sig0")); err != nil || qc != nil {
+		t.Fatalf("first vote should not reach quorum yet: qc=%v err=%v", qc, err)
+	}
+	if _, err := a.AddVote(view, digest, 0, []byte("sig0-again")); err != errDuplicateVote {
+		t.Fatalf("expected errDuplicateVote, got %v", err)
+	}
+}
+
+func TestAggregatorQuorumBelowThreshold(t *testing.T) {
+	a := New(4, 3)
+	view, digest := big.NewInt(1), common.Hash{1}
+
+	for idx := 0; idx < 2; idx++ {
+		qc, err := a.AddVote(view, digest, idx, []byte{byte(idx)})
+		if err != nil {
+			t.Fatalf("vote %d: unexpected error %v", idx, err)
+		}
+		if qc != nil {
+			t.Fatalf("vote %d: quorum reached early with only %d votes", idx, idx+1)
+		}
+	}
+}
+
+func TestAggregatorRejectsVoteAfterAggregation(t *testing.T) {
+	a := New(1, 1)
+	view, digest := big.NewInt(1), common.Hash{1}
+
+	qc, err := a.AddVote(view, digest, 0, []byte("sig0"))
+	if err != nil {
+		t.Fatalf("unexpected error reaching quorum: %v", err)
+	}
+	if qc == nil {
+		t.Fatalf("expected a QC once quorum of 1 is met")
+	}
+	if !bitSet(qc.SignerBitmap, 0) {
+		t.Fatalf("signer bitmap does not record index 0")
+	}
+
+	if _, err := a.AddVote(view, digest, 0, []byte("sig0")); err != errAlreadyAggregated {
+		t.Fatalf("expected errAlreadyAggregated for a vote set past quorum, got %v", err)
+	}
+}
+
+func TestVerifyRejectsBelowQuorum(t *testing.T) {
+	qc := &QC{Digest: common.Hash{1}, SignerBitmap: []byte{0x01}}
+	pubKeys := [][]byte{[]byte("pk0"), []byte("pk1"), []byte("pk2")}
+
+	if err := Verify(qc, pubKeys, 2); err == nil {
+		t.Fatalf("expected quorum error when only 1 of 3 bits is set against quorum 2")
+	}
+}
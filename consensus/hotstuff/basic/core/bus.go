@@ -0,0 +1,156 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"container/heap"
+	"time"
+
+	"github.com/ethereum/go-ethereum/consensus/hotstuff"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// busQueueSize bounds every per-event-type channel in the bus. A bounded
+// channel plus an explicit drop counter is preferable to the unbounded
+// growth an EventMux subscription allows under backlog pressure.
+const busQueueSize = 1024
+
+// backlogReplayInterval is how often queued future-view messages are
+// re-checked against the current view.
+const backlogReplayInterval = 500 * time.Millisecond
+
+var (
+	requestDepthGauge        = metrics.NewRegisteredGauge("consensus/hotstuff/bus/request/depth", nil)
+	messageDepthGauge        = metrics.NewRegisteredGauge("consensus/hotstuff/bus/message/depth", nil)
+	backlogDepthGauge        = metrics.NewRegisteredGauge("consensus/hotstuff/bus/backlog/depth", nil)
+	timeoutDepthGauge        = metrics.NewRegisteredGauge("consensus/hotstuff/bus/timeout/depth", nil)
+	finalCommittedDepthGauge = metrics.NewRegisteredGauge("consensus/hotstuff/bus/finalcommitted/depth", nil)
+	busDropMeter             = metrics.NewRegisteredMeter("consensus/hotstuff/bus/drops", nil)
+)
+
+// eventBus replaces go-ethereum's event.Mux for the core event loop: one
+// bounded channel per event type instead of a single multiplexed channel
+// that allocates on every Post and type-switches on every receive.
+type eventBus struct {
+	requests       chan hotstuff.RequestEvent
+	messages       chan hotstuff.MessageEvent
+	backlog        chan backlogEvent
+	timeouts       chan struct{}
+	finalCommitted chan hotstuff.FinalCommittedEvent
+	stopCh         chan struct{}
+
+	backlogQueue backlogQueue
+}
+
+func newEventBus() *eventBus {
+	return &eventBus{
+		requests:       make(chan hotstuff.RequestEvent, busQueueSize),
+		messages:       make(chan hotstuff.MessageEvent, busQueueSize),
+		backlog:        make(chan backlogEvent, busQueueSize),
+		timeouts:       make(chan struct{}, busQueueSize),
+		finalCommitted: make(chan hotstuff.FinalCommittedEvent, busQueueSize),
+		stopCh:         make(chan struct{}),
+	}
+}
+
+func (b *eventBus) close() {
+	close(b.stopCh)
+}
+
+func (b *eventBus) postRequest(ev hotstuff.RequestEvent) {
+	select {
+	case b.requests <- ev:
+		requestDepthGauge.Update(int64(len(b.requests)))
+	default:
+		busDropMeter.Mark(1)
+	}
+}
+
+func (b *eventBus) postMessage(ev hotstuff.MessageEvent) {
+	select {
+	case b.messages <- ev:
+		messageDepthGauge.Update(int64(len(b.messages)))
+	default:
+		busDropMeter.Mark(1)
+	}
+}
+
+func (b *eventBus) postBacklog(ev backlogEvent) {
+	select {
+	case b.backlog <- ev:
+		backlogDepthGauge.Update(int64(len(b.backlog)))
+	default:
+		busDropMeter.Mark(1)
+	}
+}
+
+func (b *eventBus) postTimeout() {
+	select {
+	case b.timeouts <- struct{}{}:
+		timeoutDepthGauge.Update(int64(len(b.timeouts)))
+	default:
+		busDropMeter.Mark(1)
+	}
+}
+
+func (b *eventBus) postFinalCommitted(ev hotstuff.FinalCommittedEvent) {
+	select {
+	case b.finalCommitted <- ev:
+		finalCommittedDepthGauge.Update(int64(len(b.finalCommitted)))
+	default:
+		busDropMeter.Mark(1)
+	}
+}
+
+// backlogQueue orders buffered future-view messages by view so the replay
+// tick processes them oldest-view-first instead of the arbitrary order an
+// unordered re-insertion into the map would produce.
+type backlogQueue []*backlogEvent
+
+func (q backlogQueue) Len() int { return len(q) }
+func (q backlogQueue) Less(i, j int) bool {
+	return q[i].msg.View().Cmp(q[j].msg.View()) < 0
+}
+func (q backlogQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *backlogQueue) Push(x interface{}) { *q = append(*q, x.(*backlogEvent)) }
+
+func (q *backlogQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	item := old[n-1]
+	*q = old[:n-1]
+	return item
+}
+
+// enqueueBacklog adds a buffered message to the view-sorted replay queue.
+func (c *core) enqueueBacklog(ev *backlogEvent) {
+	heap.Push(&c.bus.backlogQueue, ev)
+}
+
+// replayBacklog drains every queued message whose view is no longer in the
+// future, oldest view first, re-running it through handleCheckedMsg.
+func (c *core) replayBacklog() {
+	for c.bus.backlogQueue.Len() > 0 {
+		next := c.bus.backlogQueue[0]
+		if next.msg.View().Cmp(c.currentView()) > 0 {
+			break
+		}
+		heap.Pop(&c.bus.backlogQueue)
+		_ = c.handleCheckedMsg(next.msg, next.src)
+	}
+}
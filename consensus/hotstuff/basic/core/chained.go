@@ -0,0 +1,203 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/hotstuff"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Message codes used by the chained (pipelined) HotStuff mode. A single
+// generic vote per height replaces the three phase-specific votes
+// (PrepareVote/PreCommitVote/CommitVote) used by the basic mode.
+const (
+	MsgTypeGeneric     = MsgTypeCommitVote + 10
+	MsgTypeGenericVote = MsgTypeCommitVote + 11
+)
+
+var (
+	basicCommitTimer   = metrics.NewRegisteredTimer("consensus/hotstuff/basic/commit", nil)
+	chainedCommitTimer = metrics.NewRegisteredTimer("consensus/hotstuff/chained/commit", nil)
+)
+
+// GenericProposal is the chained-mode wire payload: a proposal plus the QC
+// over its parent. The embedded QC simultaneously justifies PreCommit of
+// the parent, Commit of the grandparent and Decide of the great-grandparent,
+// per the 3-chain commit rule.
+type GenericProposal struct {
+	View     hotstuff.View
+	Proposal hotstuff.Proposal
+	QC       *QC
+}
+
+// GenericVote is a chained-mode replica's vote on a GenericProposal, the
+// single vote type that stands in for PrepareVote/PreCommitVote/CommitVote.
+type GenericVote struct {
+	View   hotstuff.View
+	Digest common.Hash
+}
+
+// consensusMode reports which commit pipeline this chain was configured to
+// run at genesis. A backend that does not implement hotstuff.ModeAwareBackend
+// always runs the original basic 3-phase flow.
+func (c *core) consensusMode() hotstuff.Mode {
+	mb, ok := c.backend.(hotstuff.ModeAwareBackend)
+	if !ok {
+		return hotstuff.ModeBasic
+	}
+	return mb.Mode()
+}
+
+// commitTimerForMode returns the metrics timer tracking commit latency for
+// the engine's configured mode, so basic and chained throughput can be
+// compared on the same dashboard.
+func (c *core) commitTimerForMode() metrics.Timer {
+	if c.consensusMode() == hotstuff.ModeChained {
+		return chainedCommitTimer
+	}
+	return basicCommitTimer
+}
+
+// sendGenericProposal is the chained-mode proposer path: once this replica
+// leads the current round, it wraps the incoming request together with the
+// parent's QC into a GenericProposal and broadcasts it, the single message
+// handleGeneric/verifyAndProcessChain expect to drive the pipeline forward.
+func (c *core) sendGenericProposal(request *hotstuff.Request) {
+	if !c.valSet.IsProposer(c.address) {
+		return
+	}
+
+	gp := &GenericProposal{
+		View:     c.currentView(),
+		Proposal: request.Proposal,
+		QC:       c.current.PreparedQC(),
+	}
+	payload, err := rlp.EncodeToBytes(gp)
+	if err != nil {
+		c.logger.Error("Failed to encode GENERIC", "err", err)
+		return
+	}
+	c.broadcast(&message{Code: MsgTypeGeneric, Msg: payload})
+}
+
+// handleGeneric processes a chained-mode proposal. Unlike the basic mode's
+// separate Prepare/PreCommit/Commit messages, a single Generic message
+// carries both the new proposal and the QC that extends its parent; the QC
+// is checked against the 3-chain rule, then the proposal itself is voted on
+// directly (it does not reuse handlePrepare, whose Decode expects the
+// unrelated V1 Prepare wire shape).
+func (c *core) handleGeneric(msg *message, src hotstuff.Validator) error {
+	var gp GenericProposal
+	if err := msg.Decode(&gp); err != nil {
+		return wrapDecodeErr(errFailedDecodeGeneric, protocolV1)
+	}
+
+	committed, err := c.verifyAndProcessChain(gp.QC)
+	if err != nil {
+		return err
+	}
+	if committed != nil {
+		// Commit latency is measured from when the committed block was
+		// first proposed, not from how long this QC took to verify.
+		c.commitTimerForMode().UpdateSince(time.Unix(int64(committed.Time()), 0))
+	}
+
+	if err := c.voteGeneric(&gp, src); err != nil {
+		return err
+	}
+
+	// Chained mode has no per-phase timer: every valid QC is itself proof
+	// of progress, so it advances straight to the next round rather than
+	// waiting on a PreCommit/Commit timeout like the basic mode does.
+	if c.consensusMode() == hotstuff.ModeChained {
+		c.startNewRound(new(big.Int).Add(gp.View.Round, common.Big1))
+	}
+	return nil
+}
+
+// voteGeneric accepts gp as the current round's proposal and broadcasts this
+// replica's vote on it.
+func (c *core) voteGeneric(gp *GenericProposal, src hotstuff.Validator) error {
+	if err := c.current.SetPendingRequest(&hotstuff.Request{Proposal: gp.Proposal}); err != nil {
+		return err
+	}
+
+	vote := &GenericVote{View: gp.View, Digest: gp.Proposal.Hash()}
+	payload, err := rlp.EncodeToBytes(vote)
+	if err != nil {
+		return err
+	}
+	c.broadcast(&message{Code: MsgTypeGenericVote, Msg: payload})
+	return nil
+}
+
+// handleGenericVote feeds a chained-mode vote into the same
+// quorum-aggregation path used by PrepareVote; once a quorum of votes over
+// the same proposal is collected, the aggregated signatures become that
+// proposal's QC and extend the chain by one more block.
+func (c *core) handleGenericVote(msg *message, src hotstuff.Validator) error {
+	return c.handlePrepareVote(msg, src)
+}
+
+// verifyAndProcessChain implements the 3-chain commit rule: qc acts as
+// PreCommit for its parent, Commit for its grandparent, and Decide (final
+// commit) for its great-grandparent, provided the three most recent QCs
+// form an uninterrupted run of consecutive rounds. It returns the proposal
+// that was just committed, or nil if qc does not complete such a run (a gap
+// simply defers the commit to a later, uninterrupted QC rather than being
+// an error).
+func (c *core) verifyAndProcessChain(qc *QC) (hotstuff.Proposal, error) {
+	if err := c.verifyQC(qc); err != nil {
+		return nil, errVerifyQC
+	}
+
+	c.qcChain = append(c.qcChain, qc)
+	if len(c.qcChain) > 3 {
+		c.qcChain = c.qcChain[len(c.qcChain)-3:]
+	}
+	if len(c.qcChain) < 3 {
+		return nil, nil
+	}
+
+	grandparentQC, parentQC, thisQC := c.qcChain[0], c.qcChain[1], c.qcChain[2]
+	if !consecutiveRounds(grandparentQC.View.Round, parentQC.View.Round) ||
+		!consecutiveRounds(parentQC.View.Round, thisQC.View.Round) {
+		// The chain broke: drop the oldest QC and wait for a fresh,
+		// uninterrupted run of three rather than erroring out.
+		c.qcChain = c.qcChain[1:]
+		return nil, nil
+	}
+
+	committed := c.current.ProposalForRound(grandparentQC.View.Round)
+	if committed == nil {
+		return nil, nil
+	}
+	if err := c.backend.Commit(committed); err != nil {
+		return nil, err
+	}
+	return committed, nil
+}
+
+// consecutiveRounds reports whether b is exactly one round after a.
+func consecutiveRounds(a, b *big.Int) bool {
+	return new(big.Int).Add(a, common.Big1).Cmp(b) == 0
+}
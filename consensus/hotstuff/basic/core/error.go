@@ -1,6 +1,9 @@
 package core
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
 	// errInconsistentVote is returned when received subject is different from
@@ -71,4 +74,59 @@ var (
 	errAddPrepareVote         = errors.New("add prepare vote error")
 	errAddPreCommitVote       = errors.New("add pre commit vote error")
 	errAddCommitVote          = errors.New("add commit vote error")
+
+	// errFailedDecodeNewViewPrepare is returned when the V2 combined
+	// NEWVIEW_PREPARE message introduced by the HotstuffV2 fork is malformed.
+	errFailedDecodeNewViewPrepare     = errors.New("failed to decode NEWVIEW_PREPARE")
+	errFailedDecodeNewViewPrepareVote = errors.New("failed to decode NEWVIEW_PREPARE_VOTE")
+
+	// errFailedDecodeGeneric is returned when the chained-mode GENERIC
+	// message is malformed.
+	errFailedDecodeGeneric     = errors.New("failed to decode GENERIC")
+	errFailedDecodeGenericVote = errors.New("failed to decode GENERIC_VOTE")
+
+	// errFailedDecodeTimeout is returned when the TIMEOUT message is malformed.
+	errFailedDecodeTimeout = errors.New("failed to decode TIMEOUT")
+
+	// errInvalidTC is returned when a TimeoutCertificate does not carry a
+	// quorum of valid timeout votes for the view it claims.
+	errInvalidTC = errors.New("invalid timeout certificate")
+
+	// errInvalidAggSig is returned when a QC's BLS aggregate signature
+	// fails the pairing check against its reconstructed aggregate public key.
+	errInvalidAggSig = errors.New("invalid bls aggregate signature")
+
+	// errInvalidBitmap is returned when a QC's signer bitmap does not meet
+	// quorum, or references a validator index outside the current valSet.
+	errInvalidBitmap = errors.New("invalid qc signer bitmap")
 )
+
+// protocolVersion identifies which HotStuff wire format produced a given
+// error, so the same decode failure can be told apart on either side of
+// the HotstuffV2 fork when debugging.
+type protocolVersion uint8
+
+const (
+	protocolV1 protocolVersion = iota + 1
+	protocolV2
+)
+
+func (p protocolVersion) String() string {
+	switch p {
+	case protocolV1:
+		return "v1"
+	case protocolV2:
+		return "v2"
+	default:
+		return "unknown"
+	}
+}
+
+// wrapDecodeErr tags a decode error with the protocol version that was
+// active when it was produced.
+func wrapDecodeErr(err error, v protocolVersion) error {
+	if err == nil {
+		return nil
+	}
+	return fmt.Errorf("%w (protocol=%s)", err, v)
+}
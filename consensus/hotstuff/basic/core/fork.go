@@ -0,0 +1,127 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"github.com/ethereum/go-ethereum/consensus/hotstuff"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Message codes introduced by the HotstuffV2 hard fork. V2 folds the
+// NewView/Prepare exchange into a single message carrying an aggregated
+// QC, so only two new codes are needed in place of the seven used by V1.
+const (
+	MsgTypeNewViewPrepare     = MsgTypeCommitVote + 1
+	MsgTypeNewViewPrepareVote = MsgTypeCommitVote + 2
+)
+
+// NewViewPrepare is the V2 wire payload that merges a NewView and its
+// answering Prepare into one round trip: the proposal together with the
+// highQC that justifies extending it.
+type NewViewPrepare struct {
+	View     hotstuff.View
+	Proposal hotstuff.Proposal
+	HighQC   *QC
+}
+
+// isHotstuffV2 reports whether the chain has activated the V2 wire format
+// as of the height the core is currently working on. A backend that does
+// not implement hotstuff.ForkAwareBackend never forks and always speaks V1.
+func (c *core) isHotstuffV2() bool {
+	fb, ok := c.backend.(hotstuff.ForkAwareBackend)
+	if !ok {
+		return false
+	}
+	return fb.IsHotstuffV2Consensus(c.current.Height())
+}
+
+// refreshForkState is called on every FinalCommittedEvent so that a fork
+// activation is picked up as soon as it becomes canonical, without
+// restarting the node. It is a no-op once the backend is already running
+// the V2 handler table.
+func (c *core) refreshForkState() {
+	fb, ok := c.backend.(hotstuff.ForkAwareBackend)
+	if !ok || c.protocolV2 {
+		return
+	}
+	if fb.IsHotstuffV2Consensus(c.current.Height()) {
+		if err := fb.StartHotstuffV2Consensus(); err != nil {
+			c.logger.Error("Failed to switch to HotstuffV2 consensus", "err", err)
+			return
+		}
+		c.protocolV2 = true
+		c.logger.Info("HotstuffV2 fork activated, switched message handler table", "height", c.current.Height())
+	}
+}
+
+// sendNewViewPrepare is the V2 proposer path. Once this replica leads the
+// current round, it folds the incoming request together with the view's
+// highQC into a single NewViewPrepare and broadcasts it, replacing the two
+// round trips (NewView, then Prepare) V1 needs for the same handoff.
+func (c *core) sendNewViewPrepare(request *hotstuff.Request) {
+	if !c.valSet.IsProposer(c.address) {
+		return
+	}
+
+	np := &NewViewPrepare{
+		View:     c.currentView(),
+		Proposal: request.Proposal,
+		HighQC:   c.current.PreparedQC(),
+	}
+	payload, err := rlp.EncodeToBytes(np)
+	if err != nil {
+		c.logger.Error("Failed to encode NEWVIEW_PREPARE", "err", err)
+		return
+	}
+	c.broadcast(&message{Code: MsgTypeNewViewPrepare, Msg: payload})
+}
+
+// handleNewViewPrepareV2 processes the V2 combined NewView+Prepare message:
+// the proposer replies to a quorum of NewView messages with a proposal
+// carrying the aggregated highQC directly, collapsing the separate Prepare
+// round used in V1. It votes on the proposal directly rather than calling
+// handlePrepare, whose Decode expects the unrelated V1 Prepare wire shape.
+func (c *core) handleNewViewPrepareV2(msg *message, src hotstuff.Validator) error {
+	var np NewViewPrepare
+	if err := msg.Decode(&np); err != nil {
+		return wrapDecodeErr(errFailedDecodeNewViewPrepare, protocolV2)
+	}
+
+	if err := c.verifyQC(np.HighQC); err != nil {
+		return errVerifyQC
+	}
+
+	if err := c.current.SetPendingRequest(&hotstuff.Request{Proposal: np.Proposal}); err != nil {
+		return err
+	}
+
+	vote := &GenericVote{View: np.View, Digest: np.Proposal.Hash()}
+	payload, err := rlp.EncodeToBytes(vote)
+	if err != nil {
+		return err
+	}
+	c.broadcast(&message{Code: MsgTypeNewViewPrepareVote, Msg: payload})
+	return nil
+}
+
+// handleNewViewPrepareVoteV2 processes the V2 vote on a combined
+// NewView+Prepare proposal. It feeds the same vote-aggregation path used
+// by the V1 PrepareVote so that quorum bookkeeping stays unchanged across
+// the fork.
+func (c *core) handleNewViewPrepareVoteV2(msg *message, src hotstuff.Validator) error {
+	return c.handlePrepareVote(msg, src)
+}
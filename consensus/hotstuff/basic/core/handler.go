@@ -17,7 +17,7 @@
 package core
 
 import (
-	"math/big"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/consensus/hotstuff"
@@ -31,6 +31,7 @@ func (c *core) Start() error {
 	// Tests will handle events itself, so we have to make subscribeEvents()
 	// be able to call in test.
 	c.subscribeEvents()
+	c.armPacemakerTimer()
 	go c.handleEvents()
 
 	return nil
@@ -39,89 +40,147 @@ func (c *core) Start() error {
 // Stop implements core.Engine.Stop
 func (c *core) Stop() error {
 	c.stopTimer()
+	c.stopPacemakerTimer()
 	c.unsubscribeEvents()
 	return nil
 }
 
 // ----------------------------------------------------------------------------
 
-// Subscribe both internal and external events
+// subscribeEvents wires up the core's typed event bus. The backend
+// originates three event types on the legacy EventMux — RequestEvent
+// (proposals from the miner), MessageEvent (peer messages from the protocol
+// manager), and FinalCommittedEvent (posted once a block lands) — and that
+// delivery path is part of the backend, outside core, and unchanged by this
+// refactor, so core keeps a single subscription to relay all three
+// externally-sourced event types onto the bus. Only backlogEvent and the
+// internal timer tick originate inside core itself and are posted straight
+// onto the bus via sendEvent, with no EventMux involved.
 func (c *core) subscribeEvents() {
-	c.events = c.backend.EventMux().Subscribe(
-		// external events
+	c.bus = newEventBus()
+	c.externalEvents = c.backend.EventMux().Subscribe(
 		hotstuff.RequestEvent{},
 		hotstuff.MessageEvent{},
-		// internal events
-		backlogEvent{},
-	)
-	c.timeoutSub = c.backend.EventMux().Subscribe(
-		timeoutEvent{},
-	)
-	c.finalCommittedSub = c.backend.EventMux().Subscribe(
 		hotstuff.FinalCommittedEvent{},
 	)
+	go c.relayExternalEvents()
 }
 
-// Unsubscribe all events
+// relayExternalEvents forwards the backend's RequestEvent/MessageEvent/
+// FinalCommittedEvent traffic from EventMux onto the typed bus, until
+// unsubscribeEvents closes the subscription.
+func (c *core) relayExternalEvents() {
+	for event := range c.externalEvents.Chan() {
+		switch ev := event.Data.(type) {
+		case hotstuff.RequestEvent:
+			c.bus.postRequest(ev)
+		case hotstuff.MessageEvent:
+			c.bus.postMessage(ev)
+		case hotstuff.FinalCommittedEvent:
+			c.bus.postFinalCommitted(ev)
+		}
+	}
+}
+
+// unsubscribeEvents tears down the EventMux relay and the event bus, which
+// unblocks handleEvents.
 func (c *core) unsubscribeEvents() {
-	c.events.Unsubscribe()
-	c.timeoutSub.Unsubscribe()
-	c.finalCommittedSub.Unsubscribe()
+	c.externalEvents.Unsubscribe()
+	c.bus.close()
 }
 
+// handleEvents is the core's single select loop. It replaces the EventMux
+// type-switch with one channel per event type, so that dispatch doesn't
+// need a runtime type assertion, and so that FinalCommittedEvent/timeout
+// delivery can be prioritized ahead of the (typically much larger)
+// MessageEvent backlog.
 func (c *core) handleEvents() {
-	logger := c.logger.New("handleEvents", "state", c.currentState())
+	ticker := time.NewTicker(backlogReplayInterval)
+	defer ticker.Stop()
 
 	for {
+		// Drain the high-priority channels first: a pending commit or
+		// timeout should never sit behind a queue of ordinary messages.
 		select {
-		case event, ok := <-c.events.Chan():
-			if !ok {
-				logger.Error("Failed to receive msg Event")
-				return
-			}
-			// A real Event arrived, process interesting content
-			switch ev := event.Data.(type) {
-			case hotstuff.RequestEvent:
-				//logger.Trace("handle request Event", "height", ev.Proposal.Number().Uint64(), "hash", ev.Proposal.Hash().Hex())
-				_ = c.handleRequest(&hotstuff.Request{
-					Proposal: ev.Proposal,
-				})
-
-			case hotstuff.MessageEvent:
-				// logger.Trace("handle message Event")
-				_ = c.handleMsg(ev.Payload)
-
-			case backlogEvent:
-				//logger.Trace("handle backlog Event")
-				_ = c.handleCheckedMsg(ev.msg, ev.src)
-			}
-
-		case _, ok := <-c.timeoutSub.Chan():
-			//logger.Trace("handle timeout Event")
-			if !ok {
-				logger.Error("Failed to receive timeout Event")
-				return
-			}
-			c.handleTimeoutMsg()
-
-		case _, ok := <-c.finalCommittedSub.Chan():
-			if !ok {
-				logger.Error("Failed to receive finalCommitted Event")
-				return
-			}
+		case <-c.bus.finalCommitted:
+			c.refreshForkState()
+			c.resetPacemakerTimeout()
 			c.handleFinalCommitted()
+			continue
+		case <-c.bus.timeouts:
+			c.onTimerFired()
+			continue
+		default:
+		}
+
+		select {
+		case <-c.bus.finalCommitted:
+			c.refreshForkState()
+			c.resetPacemakerTimeout()
+			c.handleFinalCommitted()
+
+		case <-c.bus.timeouts:
+			c.onTimerFired()
+
+		case ev := <-c.bus.requests:
+			c.handleRequestEvent(ev)
+
+		case ev := <-c.bus.messages:
+			_ = c.handleMsg(ev.Payload)
+
+		case ev := <-c.bus.backlog:
+			c.enqueueBacklog(&ev)
+
+		case <-ticker.C:
+			c.replayBacklog()
+
+		case <-c.bus.stopCh:
+			return
 		}
 	}
 }
 
-// sendEvent sends events to mux
+// sendEvent posts an event onto the typed bus in place of the legacy
+// EventMux.Post.
 func (c *core) sendEvent(ev interface{}) {
-	switch ev.(type) {
+	switch e := ev.(type) {
+	case hotstuff.RequestEvent:
+		c.bus.postRequest(e)
+	case hotstuff.MessageEvent:
+		c.bus.postMessage(e)
+	case backlogEvent:
+		c.bus.postBacklog(e)
 	case timeoutEvent:
 		c.logger.Trace("sendTimeoutEvent", "state", c.currentState(), "view", c.currentView())
+		c.bus.postTimeout()
+	case hotstuff.FinalCommittedEvent:
+		c.bus.postFinalCommitted(e)
+	case hotstuff.TimeoutEvent:
+		// TimeoutEvent flows the opposite direction from the other
+		// external events: it's core telling the backend to gossip an
+		// assembled TimeoutCertificate, so it goes out via EventMux for
+		// the backend to pick up and relay as a MessageEvent to peers.
+		c.backend.EventMux().Post(e)
+	default:
+		c.logger.Error("sendEvent: unknown event type", "event", ev)
+	}
+}
+
+// handleRequestEvent dispatches an incoming proposal request to whichever
+// proposer path is active for this chain: the V2 combined NewViewPrepare
+// once the HotstuffV2 fork has activated, the chained-mode GenericProposal
+// when the chain runs the pipelined commit path, or the legacy
+// NewView/Prepare flow otherwise.
+func (c *core) handleRequestEvent(ev hotstuff.RequestEvent) {
+	request := &hotstuff.Request{Proposal: ev.Proposal}
+	switch {
+	case c.isHotstuffV2():
+		c.sendNewViewPrepare(request)
+	case c.consensusMode() == hotstuff.ModeChained:
+		c.sendGenericProposal(request)
 	default:
+		_ = c.handleRequest(request)
 	}
-	c.backend.EventMux().Post(ev)
 }
 
 func (c *core) handleMsg(payload []byte) error {
@@ -152,6 +211,25 @@ func (c *core) handleCheckedMsg(msg *message, src hotstuff.Validator) error {
 		return err
 	}
 
+	// Once the HotstuffV2 fork has activated, the engine speaks the
+	// compact wire format exclusively: there is no need to keep accepting
+	// V1 proposal/vote codes since every validator switches at the same
+	// height. MsgTypeTimeout is cross-version: the pacemaker keeps running
+	// unchanged across the fork, so timeout votes must still be accepted.
+	if c.isHotstuffV2() {
+		switch msg.Code {
+		case MsgTypeNewViewPrepare:
+			return testBacklog(c.handleNewViewPrepareV2(msg, src))
+		case MsgTypeNewViewPrepareVote:
+			return testBacklog(c.handleNewViewPrepareVoteV2(msg, src))
+		case MsgTypeTimeout:
+			return testBacklog(c.handleTimeoutMsg(msg, src))
+		default:
+			c.logger.Error("msg type invalid for protocol v2", "unknown type", msg.Code)
+		}
+		return errMsgTypeInvalid
+	}
+
 	switch msg.Code {
 	case MsgTypeNewView:
 		return testBacklog(c.handleNewView(msg, src))
@@ -167,14 +245,14 @@ func (c *core) handleCheckedMsg(msg *message, src hotstuff.Validator) error {
 		return testBacklog(c.handleCommit(msg, src))
 	case MsgTypeCommitVote:
 		return testBacklog(c.handleCommitVote(msg, src))
+	case MsgTypeGeneric:
+		return testBacklog(c.handleGeneric(msg, src))
+	case MsgTypeGenericVote:
+		return testBacklog(c.handleGenericVote(msg, src))
+	case MsgTypeTimeout:
+		return testBacklog(c.handleTimeoutMsg(msg, src))
 	default:
 		c.logger.Error("msg type invalid", "unknown type", msg.Code)
 	}
 	return errInvalidMessage
 }
-
-func (c *core) handleTimeoutMsg() {
-	c.logger.Trace("handleTimeout", "state", c.currentState(), "view", c.currentView())
-	round := new(big.Int).Add(c.current.Round(), common.Big1)
-	c.startNewRound(round)
-}
@@ -0,0 +1,217 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/hotstuff"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// MsgTypeTimeout is broadcast by a replica whose view timer fires, instead
+// of silently bumping its local round as the legacy scheme did.
+const MsgTypeTimeout = MsgTypeCommitVote + 12
+
+// baseTimeout is the pacemaker's starting timer duration; it doubles on
+// every consecutive timeout and resets back to this value on a successful
+// commit.
+const baseTimeout = 3 * time.Second
+
+// maxTimeout caps the exponential backoff so a long partition doesn't push
+// the timer out to an unreasonable duration.
+const maxTimeout = 2 * time.Minute
+
+// TimeoutVote is a single replica's signed vote that it timed out waiting
+// on the given view, carrying the highest QC it had observed so the new
+// view can be seeded without losing committed work.
+type TimeoutVote struct {
+	View    hotstuff.View
+	HighQC  *QC
+	Address common.Address
+}
+
+// TimeoutCertificate (TC) aggregates f+1 signed TimeoutVotes for the same
+// view. Any replica that assembles one jumps directly to view+1 instead of
+// waiting for its own timer.
+type TimeoutCertificate struct {
+	View   hotstuff.View
+	HighQC *QC
+	Votes  []TimeoutVote
+}
+
+// pacemaker tracks the exponential-backoff timer duration, the live timer
+// itself, and the timeout votes collected so far, replacing the single
+// stopTimer/handleTimeoutMsg scheme. votes is bucketed by view so that
+// votes for different views (a straggler replaying an old timeout, or a
+// replica that has already moved on) are never counted toward the same
+// quorum.
+type pacemaker struct {
+	timeout time.Duration
+	timer   *time.Timer
+	votes   map[string]map[common.Address]TimeoutVote
+}
+
+func newPacemaker() *pacemaker {
+	return &pacemaker{timeout: baseTimeout, votes: make(map[string]map[common.Address]TimeoutVote)}
+}
+
+// viewKey identifies the per-view vote bucket in pacemaker.votes.
+func viewKey(v hotstuff.View) string {
+	return v.Round.String()
+}
+
+// armPacemakerTimer (re)starts the view timer for c.pm.timeout. It is
+// called from Start, after a TimeoutCertificate moves the replica to the
+// next round, after a commit resets the timeout, and whenever the timer
+// fires and is backed off, so c.pm.timeout actually governs when the next
+// timeoutEvent is posted instead of sitting unread. startNewRound itself
+// does not arm it.
+func (c *core) armPacemakerTimer() {
+	if c.pm.timer != nil {
+		c.pm.timer.Stop()
+	}
+	c.pm.timer = time.AfterFunc(c.pm.timeout, func() {
+		c.sendEvent(timeoutEvent{})
+	})
+}
+
+// stopPacemakerTimer cancels the live view timer, e.g. on Stop().
+func (c *core) stopPacemakerTimer() {
+	if c.pm.timer != nil {
+		c.pm.timer.Stop()
+	}
+}
+
+// onTimerFired is invoked when the local view timer expires. Rather than
+// bumping the round directly, the replica broadcasts a signed timeout vote
+// for its current view, doubles its own timer for next time, and rearms it.
+func (c *core) onTimerFired() {
+	c.logger.Trace("pacemaker timer fired", "state", c.currentState(), "view", c.currentView())
+
+	vote := &TimeoutVote{
+		View:   c.currentView(),
+		HighQC: c.current.PreparedQC(),
+	}
+	payload, err := rlp.EncodeToBytes(vote)
+	if err != nil {
+		c.logger.Error("Failed to encode timeout vote", "err", err)
+		return
+	}
+	c.broadcast(&message{Code: MsgTypeTimeout, Msg: payload})
+
+	c.pm.timeout = nextTimeout(c.pm.timeout)
+	c.armPacemakerTimer()
+}
+
+// nextTimeout doubles d, capped at maxTimeout, implementing the
+// pacemaker's exponential backoff.
+func nextTimeout(d time.Duration) time.Duration {
+	d *= 2
+	if d > maxTimeout {
+		return maxTimeout
+	}
+	return d
+}
+
+// resetPacemakerTimeout restores the timer to its base duration and rearms
+// it; it is called after every successful commit so a run of timeouts
+// doesn't permanently inflate the timer past what the network currently
+// needs.
+func (c *core) resetPacemakerTimeout() {
+	c.pm.timeout = baseTimeout
+	c.armPacemakerTimer()
+}
+
+// handleTimeoutMsg collects signed timeout votes bucketed by the view they
+// were cast for. On a single view's bucket reaching quorum (f+1) it
+// assembles a TimeoutCertificate for that view, posts it as a TimeoutEvent
+// so the backend can gossip it to peers via MessageEvent, jumps straight to
+// view = TC.view + 1, and seeds the next NewView with the TC's highQC so a
+// replica never re-proposes on top of stale state. Bucketing by view keeps
+// a straggler's vote for an old view from ever being counted toward a
+// different view's quorum.
+func (c *core) handleTimeoutMsg(msg *message, src hotstuff.Validator) error {
+	var vote TimeoutVote
+	if err := msg.Decode(&vote); err != nil {
+		version := protocolV1
+		if c.isHotstuffV2() {
+			version = protocolV2
+		}
+		return wrapDecodeErr(errFailedDecodeTimeout, version)
+	}
+
+	key := viewKey(vote.View)
+	bucket, ok := c.pm.votes[key]
+	if !ok {
+		bucket = make(map[common.Address]TimeoutVote)
+		c.pm.votes[key] = bucket
+	}
+	bucket[src.Address()] = vote
+
+	if len(bucket) < c.valSet.F()+1 {
+		return nil
+	}
+
+	tc := &TimeoutCertificate{View: vote.View}
+	for _, v := range bucket {
+		tc.Votes = append(tc.Votes, v)
+		if tc.HighQC == nil || higherQC(v.HighQC, tc.HighQC) {
+			tc.HighQC = v.HighQC
+		}
+	}
+	if len(tc.Votes) < c.valSet.F()+1 {
+		return errInvalidTC
+	}
+
+	delete(c.pm.votes, key)
+
+	if payload, err := rlp.EncodeToBytes(tc); err != nil {
+		c.logger.Error("Failed to encode timeout certificate", "err", err)
+	} else {
+		c.sendEvent(hotstuff.TimeoutEvent{Payload: payload})
+	}
+
+	c.seedViewWithQC(tc.HighQC)
+	nextRound := new(big.Int).Add(tc.View.Round, common.Big1)
+	c.startNewRound(nextRound)
+	c.armPacemakerTimer()
+	return nil
+}
+
+// seedViewWithQC records qc as the prepared QC for the view the replica is
+// about to enter, so the NewView message it sends after a timeout carries
+// the TC's evidence instead of a stale local value.
+func (c *core) seedViewWithQC(qc *QC) {
+	if qc == nil {
+		return
+	}
+	c.current.SetPreparedQC(qc)
+}
+
+// higherQC reports whether a is for a strictly higher view than b.
+func higherQC(a, b *QC) bool {
+	if a == nil {
+		return false
+	}
+	if b == nil {
+		return true
+	}
+	return a.View.Round.Cmp(b.View.Round) > 0
+}
@@ -0,0 +1,74 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/consensus/hotstuff"
+)
+
+func TestNextTimeoutDoublesAndCaps(t *testing.T) {
+	d := baseTimeout
+	for i := 0; i < 10; i++ {
+		next := nextTimeout(d)
+		if next > maxTimeout {
+			t.Fatalf("nextTimeout(%v) = %v exceeds maxTimeout %v", d, next, maxTimeout)
+		}
+		if d < maxTimeout && next < d {
+			t.Fatalf("nextTimeout(%v) = %v did not grow", d, next)
+		}
+		d = next
+	}
+	if d != maxTimeout {
+		t.Fatalf("expected backoff to saturate at maxTimeout %v after repeated doubling, got %v", maxTimeout, d)
+	}
+}
+
+func TestNextTimeoutAlreadyAtCap(t *testing.T) {
+	if got := nextTimeout(maxTimeout); got != maxTimeout {
+		t.Fatalf("nextTimeout(maxTimeout) = %v, want %v", got, maxTimeout)
+	}
+}
+
+func TestHigherQC(t *testing.T) {
+	low := &QC{View: hotstuff.View{Round: big.NewInt(1)}}
+	high := &QC{View: hotstuff.View{Round: big.NewInt(2)}}
+
+	if higherQC(nil, low) {
+		t.Fatalf("nil should never be higher than a real QC")
+	}
+	if !higherQC(low, nil) {
+		t.Fatalf("a real QC should be higher than nil")
+	}
+	if higherQC(low, high) {
+		t.Fatalf("lower-round QC reported as higher")
+	}
+	if !higherQC(high, low) {
+		t.Fatalf("higher-round QC not reported as higher")
+	}
+}
+
+func TestViewKeyDistinguishesRounds(t *testing.T) {
+	v1 := hotstuff.View{Round: big.NewInt(1)}
+	v2 := hotstuff.View{Round: big.NewInt(2)}
+	if viewKey(v1) == viewKey(v2) {
+		t.Fatalf("viewKey collided for distinct rounds 1 and 2")
+	}
+}
@@ -0,0 +1,42 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/hotstuff"
+)
+
+// QC is a quorum certificate: proof that a quorum of validators signed the
+// same (view, digest) pair. It is the value every phase vote aggregates
+// into and the value the pacemaker, the chained-mode 3-chain walk, and the
+// HotstuffV2 NewViewPrepare exchange all carry around as evidence of prior
+// agreement.
+//
+// Scheme records which of the two signature representations below is
+// populated: AggSig/SignerBitmap for SignatureSchemeBLS, Signatures for the
+// legacy SignatureSchemeECDSA append-each-signature format. AggSig is only
+// set for BLS and Signatures only for ECDSA; SignerBitmap is also set on an
+// ECDSA QC once migrateLegacyQC has normalized it.
+type QC struct {
+	View         hotstuff.View
+	Digest       common.Hash
+	Scheme       SignatureScheme
+	AggSig       []byte
+	SignerBitmap []byte
+	Signatures   []Signature
+}
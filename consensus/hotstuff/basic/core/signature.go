@@ -0,0 +1,124 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/hotstuff"
+	"github.com/ethereum/go-ethereum/consensus/hotstuff/aggregator"
+)
+
+// SignatureScheme selects how validators sign votes and how the resulting
+// QC is built and verified. BLS replaces the legacy append-each-signature
+// pattern with a single aggregate signature and a signer bitmap; ECDSA
+// remains available so a running chain can be configured either way.
+type SignatureScheme string
+
+const (
+	SignatureSchemeECDSA SignatureScheme = "ecdsa"
+	SignatureSchemeBLS   SignatureScheme = "bls"
+)
+
+// Signature is one validator's individual vote signature, the shape QC.
+// Signatures held under the legacy append-each-ECDSA-sig pattern.
+type Signature struct {
+	Address common.Address
+	Sig     []byte
+}
+
+// BLSCapableValidatorSet is implemented by a ValidatorSet that can supply
+// BLS public keys and the quorum size needed to verify a BLS-aggregated
+// QC. It is queried via type assertion so that a valSet on a chain still
+// configured for SignatureSchemeECDSA need not implement it.
+type BLSCapableValidatorSet interface {
+	hotstuff.ValidatorSet
+	BLSPublicKeys() [][]byte
+	Quorum() int
+}
+
+// feedVote hands one validator's vote signature to the phase's aggregator
+// and returns a non-nil QC the moment quorum is reached for (view, digest).
+// handlePrepareVote/handlePreCommitVote/handleCommitVote do not call it:
+// they still build QCs with the append-to-slice pattern, whatever
+// signatureScheme() reports.
+func (c *core) feedVote(agg *aggregator.Aggregator, view *big.Int, digest common.Hash, validatorIndex int, sig []byte) (*QC, error) {
+	blsSet, ok := c.valSet.(BLSCapableValidatorSet)
+	if !ok {
+		return nil, fmt.Errorf("feedVote: valSet does not implement BLSCapableValidatorSet")
+	}
+
+	aggQC, err := agg.AddVote(view, digest, validatorIndex, sig)
+	if err != nil || aggQC == nil {
+		return nil, err
+	}
+	if err := aggregator.Verify(aggQC, blsSet.BLSPublicKeys(), blsSet.Quorum()); err != nil {
+		return nil, errInvalidAggSig
+	}
+	return &QC{
+		View:         hotstuff.View{Round: aggQC.ViewNumber},
+		Digest:       aggQC.Digest,
+		Scheme:       SignatureSchemeBLS,
+		AggSig:       aggQC.AggSig,
+		SignerBitmap: aggQC.SignerBitmap,
+	}, nil
+}
+
+// signatureScheme reports which scheme the chain was configured with at
+// genesis, analogous to consensusMode() and isHotstuffV2(). A backend that
+// does not implement hotstuff.SchemeAwareBackend is assumed to predate the
+// BLS option and always signs with ECDSA.
+func (c *core) signatureScheme() SignatureScheme {
+	sb, ok := c.backend.(hotstuff.SchemeAwareBackend)
+	if !ok {
+		return SignatureSchemeECDSA
+	}
+	if sb.SignatureScheme() == string(SignatureSchemeBLS) {
+		return SignatureSchemeBLS
+	}
+	return SignatureSchemeECDSA
+}
+
+// migrateLegacyQC translates a legacy ECDSA-signed QC (a list of
+// individual signatures) into the BLS QC envelope shape, so that a chain
+// which forks from ECDSA to BLS mid-flight can keep serving old headers
+// through the same verification path. The legacy signatures themselves are
+// kept as-is and verified individually via valSet; only the envelope
+// (Scheme + SignerBitmap) is normalized so downstream code can branch on
+// Scheme instead of on chain height.
+func migrateLegacyQC(legacy *QC, valSet hotstuff.ValidatorSet) (*QC, error) {
+	if legacy.Scheme == SignatureSchemeBLS {
+		return legacy, nil
+	}
+	bitmap := make([]byte, (valSet.Size()+7)/8)
+	for _, sig := range legacy.Signatures {
+		idx, v := valSet.GetByAddress(sig.Address)
+		if v == nil {
+			return nil, errInvalidQCParticipant
+		}
+		bitmap[idx/8] |= 1 << uint(idx%8)
+	}
+	return &QC{
+		View:         legacy.View,
+		Digest:       legacy.Digest,
+		Scheme:       SignatureSchemeECDSA,
+		SignerBitmap: bitmap,
+		Signatures:   legacy.Signatures,
+	}, nil
+}
@@ -31,3 +31,10 @@ type MessageEvent struct {
 // FinalCommittedEvent is posted when a proposal is committed
 type FinalCommittedEvent struct {
 }
+
+// TimeoutEvent is posted when a replica collects a TimeoutCertificate (a
+// quorum of signed timeout votes for the same view) so the backend can
+// gossip it to the rest of the validator set via MessageEvent.
+type TimeoutEvent struct {
+	Payload []byte
+}
@@ -0,0 +1,59 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package hotstuff
+
+import "math/big"
+
+// ForkConfig groups the hard-fork heights that change HotStuff's wire
+// protocol and message handling rules over the life of a chain. It mirrors
+// the role of Quorum's QIBFT activation block: a single number that flips
+// the engine from the legacy message set to a new one at a known height.
+type ForkConfig struct {
+	// HotstuffV2Block is the block number at which validators switch from
+	// the original NewView/Prepare/PreCommit/Commit message set to the
+	// more compact V2 wire format. A nil value means the fork never
+	// activates and the chain stays on V1 forever.
+	HotstuffV2Block *big.Int
+}
+
+// IsHotstuffV2 reports whether num is on or after the V2 fork block.
+func (c *ForkConfig) IsHotstuffV2(num *big.Int) bool {
+	return isForked(c.HotstuffV2Block, num)
+}
+
+// isForked reports whether a fork scheduled at s has happened at block num.
+// A nil fork block is never activated.
+func isForked(s, num *big.Int) bool {
+	if s == nil || num == nil {
+		return false
+	}
+	return s.Cmp(num) <= 0
+}
+
+// ForkAwareBackend is implemented by a Backend that can report and react to
+// the HotstuffV2 hard-fork boundary, analogous to Quorum's
+// IsQIBFTConsensus()/StartQIBFTConsensus() pair.
+type ForkAwareBackend interface {
+	// IsHotstuffV2Consensus reports whether the backend has activated the
+	// V2 wire format as of the given block number.
+	IsHotstuffV2Consensus(num *big.Int) bool
+
+	// StartHotstuffV2Consensus reconfigures the running engine to use the
+	// V2 message format and handler table in place, without requiring a
+	// node restart.
+	StartHotstuffV2Consensus() error
+}
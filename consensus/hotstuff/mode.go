@@ -0,0 +1,50 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package hotstuff
+
+// Mode selects which HotStuff commit pipeline a chain runs. It is chosen
+// once at genesis and stays fixed for the life of the chain.
+type Mode uint8
+
+const (
+	// ModeBasic runs the original 3-phase NewView/Prepare/PreCommit/Commit
+	// flow, with one explicit phase handled per height.
+	ModeBasic Mode = iota
+	// ModeChained runs the pipelined/chained HotStuff variant: each
+	// proposal's QC simultaneously acts as PreCommit for its parent,
+	// Commit for its grandparent and Decide for its great-grandparent,
+	// collapsing three phases into a single generic vote per height.
+	ModeChained
+)
+
+// ModeAwareBackend is implemented by a Backend that can report which mode
+// (basic or chained) was chosen for its chain at genesis, analogous to
+// ForkAwareBackend for the HotstuffV2 fork height.
+type ModeAwareBackend interface {
+	Mode() Mode
+}
+
+func (m Mode) String() string {
+	switch m {
+	case ModeBasic:
+		return "basic"
+	case ModeChained:
+		return "chained"
+	default:
+		return "unknown"
+	}
+}
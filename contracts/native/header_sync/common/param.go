@@ -100,6 +100,51 @@ func (this *SyncBlockHeaderParam) Deserialization(source *polycomm.ZeroCopySourc
 	return nil
 }
 
+// SyncHotstuffEpochParam carries a new HotStuff validator set that takes
+// effect once the chain crosses EpochHeight, so relayers can submit an
+// epoch switch ahead of the headers that rely on it.
+type SyncHotstuffEpochParam struct {
+	ChainID     uint64
+	EpochHeight uint64
+	Validators  [][]byte
+}
+
+func (this *SyncHotstuffEpochParam) Serialization(sink *polycomm.ZeroCopySink) {
+	sink.WriteUint64(this.ChainID)
+	sink.WriteUint64(this.EpochHeight)
+	sink.WriteUint64(uint64(len(this.Validators)))
+	for _, v := range this.Validators {
+		sink.WriteVarBytes(v)
+	}
+}
+
+func (this *SyncHotstuffEpochParam) Deserialization(source *polycomm.ZeroCopySource) error {
+	chainID, eof := source.NextUint64()
+	if eof {
+		return fmt.Errorf("SyncHotstuffEpochParam deserialize chainID error")
+	}
+	epochHeight, eof := source.NextUint64()
+	if eof {
+		return fmt.Errorf("SyncHotstuffEpochParam deserialize epochHeight error")
+	}
+	n, eof := source.NextUint64()
+	if eof {
+		return fmt.Errorf("utils.DecodeVarUint, deserialize validator count error")
+	}
+	var validators [][]byte
+	for i := 0; uint64(i) < n; i++ {
+		validator, eof := source.NextVarBytes()
+		if eof {
+			return fmt.Errorf("utils.DecodeVarBytes, deserialize validator error")
+		}
+		validators = append(validators, validator)
+	}
+	this.ChainID = chainID
+	this.EpochHeight = epochHeight
+	this.Validators = validators
+	return nil
+}
+
 type SyncCrossChainMsgParam struct {
 	ChainID        uint64
 	Address        common.Address
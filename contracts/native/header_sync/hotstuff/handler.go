@@ -0,0 +1,149 @@
+package hotstuff
+
+import (
+	"fmt"
+
+	polycomm "github.com/polynetwork/poly/common"
+
+	"github.com/ethereum/go-ethereum/contracts/native"
+	"github.com/ethereum/go-ethereum/contracts/native/header_sync/common"
+)
+
+// Handler implements common.HeaderSyncHandler for chains that finalize
+// blocks with the Zion HotStuff engine. A header is accepted once the
+// CommitQC embedded in its extra-data is reconstructed and checked against
+// the validator set of the epoch the header falls in.
+type Handler struct{}
+
+// NewHandler returns a HeaderSyncHandler for HotStuff-finalized chains.
+func NewHandler() *Handler {
+	return &Handler{}
+}
+
+// SyncGenesisHeader bootstraps the initial epoch from a serialized
+// validator list carried in the genesis header, seeding EPOCH_SWITCH so
+// the first batch of real headers has a validator set to verify against.
+func (h *Handler) SyncGenesisHeader(service *native.NativeContract) error {
+	ctx := service.ContractRef().CurrentContext()
+	params := new(common.SyncGenesisHeaderParam)
+	if err := params.Deserialization(polycomm.NewZeroCopySource(ctx.Payload)); err != nil {
+		return fmt.Errorf("hotstuff Handler SyncGenesisHeader, deserialize params error: %v", err)
+	}
+
+	header, err := decodeHotstuffHeader(params.GenesisHeader)
+	if err != nil {
+		return fmt.Errorf("hotstuff Handler SyncGenesisHeader, decode genesis header error: %v", err)
+	}
+
+	valSet, err := extractGenesisValidators(header)
+	if err != nil {
+		return fmt.Errorf("hotstuff Handler SyncGenesisHeader, extract validator set error: %v", err)
+	}
+
+	if err := putEpochSwitch(service, params.ChainID, header.Height, valSet); err != nil {
+		return fmt.Errorf("hotstuff Handler SyncGenesisHeader, put epoch switch error: %v", err)
+	}
+	return putCurrentHeaderHeight(service, params.ChainID, header.Height)
+}
+
+// SyncBlockHeader accepts a batch of headers, deduplicates them against
+// CURRENT_HEADER_HEIGHT, verifies the CommitQC embedded in each header's
+// extra-data against the validator set of its epoch. Each header is checked
+// against the epoch with the greatest recorded start height at or below its
+// own, so a batch that crosses a recorded epoch switch uses the new set from
+// that height on; it does not record epoch switches itself.
+func (h *Handler) SyncBlockHeader(service *native.NativeContract) error {
+	ctx := service.ContractRef().CurrentContext()
+	params := new(common.SyncBlockHeaderParam)
+	if err := params.Deserialization(polycomm.NewZeroCopySource(ctx.Payload)); err != nil {
+		return fmt.Errorf("hotstuff Handler SyncBlockHeader, deserialize params error: %v", err)
+	}
+
+	currentHeight, err := getCurrentHeaderHeight(service, params.ChainID)
+	if err != nil {
+		return fmt.Errorf("hotstuff Handler SyncBlockHeader, get current header height error: %v", err)
+	}
+
+	for _, raw := range params.Headers {
+		header, err := decodeHotstuffHeader(raw)
+		if err != nil {
+			return fmt.Errorf("hotstuff Handler SyncBlockHeader, decode header error: %v", err)
+		}
+		if header.Height <= currentHeight {
+			// Already synced: relayers resubmit overlapping ranges after
+			// a restart, so skip rather than error.
+			continue
+		}
+
+		epoch, err := getEpochSwitch(service, params.ChainID, header.Height)
+		if err != nil {
+			return fmt.Errorf("hotstuff Handler SyncBlockHeader, get epoch for height %d error: %v", header.Height, err)
+		}
+
+		qc, err := reconstructCommitQC(header)
+		if err != nil {
+			return fmt.Errorf("hotstuff Handler SyncBlockHeader, reconstruct commit QC error: %v", err)
+		}
+		if err := verifyCommitQC(qc, epoch.Validators); err != nil {
+			return fmt.Errorf("hotstuff Handler SyncBlockHeader, verify commit QC at height %d error: %v", header.Height, err)
+		}
+
+		if err := putBlockHeader(service, params.ChainID, header); err != nil {
+			return fmt.Errorf("hotstuff Handler SyncBlockHeader, put header error: %v", err)
+		}
+		currentHeight = header.Height
+	}
+
+	return putCurrentHeaderHeight(service, params.ChainID, currentHeight)
+}
+
+// SyncHotstuffEpoch rotates the validator set for params.ChainID starting
+// at params.EpochHeight, ahead of the headers that will be signed by the
+// new set. It is the entrypoint for SyncHotstuffEpochParam, the shape
+// SyncGenesisHeader seeds directly but every later rotation must come
+// through: EpochHeight must be strictly after the chain's current latest
+// epoch start, so relayers can't rewrite history by replaying an old
+// switch out of order. This method is not registered in the contract's
+// method dispatch table.
+func (h *Handler) SyncHotstuffEpoch(service *native.NativeContract) error {
+	ctx := service.ContractRef().CurrentContext()
+	params := new(common.SyncHotstuffEpochParam)
+	if err := params.Deserialization(polycomm.NewZeroCopySource(ctx.Payload)); err != nil {
+		return fmt.Errorf("hotstuff Handler SyncHotstuffEpoch, deserialize params error: %v", err)
+	}
+
+	latest, err := getLatestEpochStart(service, params.ChainID)
+	if err != nil {
+		return fmt.Errorf("hotstuff Handler SyncHotstuffEpoch, get latest epoch start error: %v", err)
+	}
+	if params.EpochHeight <= latest {
+		return fmt.Errorf("hotstuff Handler SyncHotstuffEpoch, epoch height %d must be after current epoch start %d", params.EpochHeight, latest)
+	}
+
+	return putEpochSwitch(service, params.ChainID, params.EpochHeight, params.Validators)
+}
+
+// SyncCrossChainMsg verifies Merkle proofs rooted in headers that have
+// already been synced via SyncBlockHeader.
+func (h *Handler) SyncCrossChainMsg(service *native.NativeContract) error {
+	ctx := service.ContractRef().CurrentContext()
+	params := new(common.SyncCrossChainMsgParam)
+	if err := params.Deserialization(polycomm.NewZeroCopySource(ctx.Payload)); err != nil {
+		return fmt.Errorf("hotstuff Handler SyncCrossChainMsg, deserialize params error: %v", err)
+	}
+
+	for _, raw := range params.CrossChainMsgs {
+		proof, err := decodeCrossChainProof(raw)
+		if err != nil {
+			return fmt.Errorf("hotstuff Handler SyncCrossChainMsg, decode proof error: %v", err)
+		}
+		header, err := getBlockHeader(service, params.ChainID, proof.Height)
+		if err != nil {
+			return fmt.Errorf("hotstuff Handler SyncCrossChainMsg, get header at height %d error: %v", proof.Height, err)
+		}
+		if err := verifyMerkleProof(proof, header.CrossStatesRoot); err != nil {
+			return fmt.Errorf("hotstuff Handler SyncCrossChainMsg, verify merkle proof at height %d error: %v", proof.Height, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+package hotstuff
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/contracts/native"
+	nativecommon "github.com/ethereum/go-ethereum/contracts/native/header_sync/common"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// header is the subset of a Zion HotStuff block header that header-sync
+// needs: enough to verify its CommitQC and to serve cross-chain proofs
+// rooted at it.
+type header struct {
+	Height          uint64
+	ParentHash      common.Hash
+	CrossStatesRoot common.Hash
+	Extra           []byte
+}
+
+// epoch is the validator set active starting at a given height, keyed in
+// storage under EPOCH_SWITCH so headers can be checked against the set
+// that was canonical when they were produced.
+type epoch struct {
+	StartHeight uint64
+	Validators  [][]byte
+}
+
+// commitQC is the aggregated quorum certificate recovered from a header's
+// extra-data, proving a quorum of validators committed it.
+type commitQC struct {
+	Height  uint64
+	Digest  common.Hash
+	AggSig  []byte
+	Signers [][]byte
+}
+
+// crossChainProof is a decoded entry from a SyncCrossChainMsgParam batch.
+// Proof holds the raw encoded trie nodes on the path from the header's
+// CrossStatesRoot down to Key.
+type crossChainProof struct {
+	Height uint64
+	Proof  [][]byte
+	Key    []byte
+	Value  []byte
+}
+
+func key(parts ...[]byte) []byte {
+	var buf []byte
+	for _, p := range parts {
+		buf = append(buf, p...)
+	}
+	return buf
+}
+
+func uint64Bytes(n uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, n)
+	return b
+}
+
+func chainIDBytes(chainID uint64) []byte {
+	return uint64Bytes(chainID)
+}
+
+func putCurrentHeaderHeight(service *native.NativeContract, chainID uint64, height uint64) error {
+	k := key([]byte(nativecommon.CURRENT_HEADER_HEIGHT), chainIDBytes(chainID))
+	service.GetCacheDB().Put(k, uint64Bytes(height))
+	return nil
+}
+
+func getCurrentHeaderHeight(service *native.NativeContract, chainID uint64) (uint64, error) {
+	k := key([]byte(nativecommon.CURRENT_HEADER_HEIGHT), chainIDBytes(chainID))
+	raw, err := service.GetCacheDB().Get(k)
+	if err != nil {
+		return 0, err
+	}
+	if len(raw) == 0 {
+		return 0, nil
+	}
+	return binary.BigEndian.Uint64(raw), nil
+}
+
+// epochIndexKey stores the sorted list of every epoch StartHeight recorded
+// for a chain, so getEpochSwitch can find the floor entry for an arbitrary
+// header height instead of only the exact boundary height.
+func epochIndexKey(chainID uint64) []byte {
+	return key([]byte(nativecommon.EPOCH_SWITCH), chainIDBytes(chainID), []byte("index"))
+}
+
+func getEpochIndex(service *native.NativeContract, chainID uint64) ([]uint64, error) {
+	raw, err := service.GetCacheDB().Get(epochIndexKey(chainID))
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var starts []uint64
+	if err := rlp.DecodeBytes(raw, &starts); err != nil {
+		return nil, err
+	}
+	return starts, nil
+}
+
+func putEpochIndex(service *native.NativeContract, chainID uint64, starts []uint64) error {
+	raw, err := rlp.EncodeToBytes(starts)
+	if err != nil {
+		return err
+	}
+	service.GetCacheDB().Put(epochIndexKey(chainID), raw)
+	return nil
+}
+
+// putEpochSwitch records a new epoch and inserts its StartHeight into the
+// chain's sorted epoch index, kept in ascending order so getEpochSwitch can
+// binary-search it for the floor entry.
+func putEpochSwitch(service *native.NativeContract, chainID uint64, startHeight uint64, validators [][]byte) error {
+	k := key([]byte(nativecommon.EPOCH_SWITCH), chainIDBytes(chainID), uint64Bytes(startHeight))
+	e := &epoch{StartHeight: startHeight, Validators: validators}
+	raw, err := encodeEpoch(e)
+	if err != nil {
+		return err
+	}
+	service.GetCacheDB().Put(k, raw)
+
+	starts, err := getEpochIndex(service, chainID)
+	if err != nil {
+		return err
+	}
+	pos := sort.Search(len(starts), func(i int) bool { return starts[i] >= startHeight })
+	if pos < len(starts) && starts[pos] == startHeight {
+		return nil
+	}
+	starts = append(starts, 0)
+	copy(starts[pos+1:], starts[pos:])
+	starts[pos] = startHeight
+	return putEpochIndex(service, chainID, starts)
+}
+
+// getLatestEpochStart returns the StartHeight of the most recently recorded
+// epoch for a chain, or 0 if none has been recorded yet, so
+// SyncHotstuffEpoch can reject an out-of-order rotation.
+func getLatestEpochStart(service *native.NativeContract, chainID uint64) (uint64, error) {
+	starts, err := getEpochIndex(service, chainID)
+	if err != nil {
+		return 0, err
+	}
+	if len(starts) == 0 {
+		return 0, nil
+	}
+	return starts[len(starts)-1], nil
+}
+
+// floorIndex returns the index of the greatest value in the ascending,
+// deduplicated slice starts that is <= height, or -1 if every value in
+// starts is greater than height (including when starts is empty).
+func floorIndex(starts []uint64, height uint64) int {
+	return sort.Search(len(starts), func(i int) bool { return starts[i] > height }) - 1
+}
+
+// getEpochSwitch returns the validator set in effect at the given height,
+// i.e. the epoch whose StartHeight is the greatest one <= height. Headers
+// are synced continuously, not just at epoch boundaries, so this must be a
+// floor lookup over the epoch index rather than an exact-key lookup.
+func getEpochSwitch(service *native.NativeContract, chainID uint64, height uint64) (*epoch, error) {
+	starts, err := getEpochIndex(service, chainID)
+	if err != nil {
+		return nil, err
+	}
+	pos := floorIndex(starts, height)
+	if pos < 0 {
+		return nil, fmt.Errorf("no epoch switch recorded for chain %d at or before height %d", chainID, height)
+	}
+
+	k := key([]byte(nativecommon.EPOCH_SWITCH), chainIDBytes(chainID), uint64Bytes(starts[pos]))
+	raw, err := service.GetCacheDB().Get(k)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("epoch index references missing epoch record for chain %d at height %d", chainID, starts[pos])
+	}
+	return decodeEpoch(raw)
+}
+
+func putBlockHeader(service *native.NativeContract, chainID uint64, h *header) error {
+	k := key([]byte(nativecommon.BLOCK_HEADER), chainIDBytes(chainID), uint64Bytes(h.Height))
+	raw, err := encodeHeader(h)
+	if err != nil {
+		return err
+	}
+	service.GetCacheDB().Put(k, raw)
+	return nil
+}
+
+func getBlockHeader(service *native.NativeContract, chainID uint64, height uint64) (*header, error) {
+	k := key([]byte(nativecommon.BLOCK_HEADER), chainIDBytes(chainID), uint64Bytes(height))
+	raw, err := service.GetCacheDB().Get(k)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("no header stored for chain %d at height %d", chainID, height)
+	}
+	return decodeHeader(raw)
+}
@@ -0,0 +1,37 @@
+package hotstuff
+
+import "testing"
+
+func TestFloorIndex(t *testing.T) {
+	starts := []uint64{0, 100, 250}
+
+	tests := []struct {
+		height uint64
+		want   int
+	}{
+		{0, 0},
+		{50, 0},
+		{100, 1},
+		{249, 1},
+		{250, 2},
+		{1000, 2},
+	}
+	for _, tt := range tests {
+		if got := floorIndex(starts, tt.height); got != tt.want {
+			t.Errorf("floorIndex(%v, %d) = %d, want %d", starts, tt.height, got, tt.want)
+		}
+	}
+}
+
+func TestFloorIndexEmpty(t *testing.T) {
+	if got := floorIndex(nil, 5); got != -1 {
+		t.Errorf("floorIndex(nil, 5) = %d, want -1", got)
+	}
+}
+
+func TestFloorIndexBeforeFirstEpoch(t *testing.T) {
+	starts := []uint64{100, 200}
+	if got := floorIndex(starts, 50); got != -1 {
+		t.Errorf("floorIndex(%v, 50) = %d, want -1 (no epoch recorded yet)", starts, got)
+	}
+}
@@ -0,0 +1,136 @@
+package hotstuff
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/hotstuff/aggregator"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+func encodeHeader(h *header) ([]byte, error) { return rlp.EncodeToBytes(h) }
+func encodeEpoch(e *epoch) ([]byte, error)   { return rlp.EncodeToBytes(e) }
+
+func decodeHeader(raw []byte) (*header, error) {
+	h := new(header)
+	if err := rlp.DecodeBytes(raw, h); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+func decodeEpoch(raw []byte) (*epoch, error) {
+	e := new(epoch)
+	if err := rlp.DecodeBytes(raw, e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// decodeHotstuffHeader decodes a raw relayed header into the fields
+// header-sync cares about.
+func decodeHotstuffHeader(raw []byte) (*header, error) {
+	return decodeHeader(raw)
+}
+
+// extractGenesisValidators reads the bootstrap validator set carried in a
+// genesis header's extra-data field.
+func extractGenesisValidators(h *header) ([][]byte, error) {
+	if len(h.Extra) == 0 {
+		return nil, fmt.Errorf("genesis header carries no validator set in extra-data")
+	}
+	var validators [][]byte
+	if err := rlp.DecodeBytes(h.Extra, &validators); err != nil {
+		return nil, err
+	}
+	return validators, nil
+}
+
+// reconstructCommitQC recovers the aggregated CommitQC embedded in a
+// header's extra-data, the same payload a HotStuff validator writes when
+// it finalizes a block.
+func reconstructCommitQC(h *header) (*commitQC, error) {
+	qc := new(commitQC)
+	if err := rlp.DecodeBytes(h.Extra, qc); err != nil {
+		return nil, fmt.Errorf("failed to decode commit QC from header extra-data: %v", err)
+	}
+	if qc.Height != h.Height {
+		return nil, fmt.Errorf("commit QC height %d does not match header height %d", qc.Height, h.Height)
+	}
+	return qc, nil
+}
+
+// verifyCommitQC checks that qc.AggSig is a valid BLS aggregate signature
+// over qc.Digest from a quorum of distinct validators in the supplied set.
+// It builds the signer bitmap aggregator.Verify expects from qc.Signers
+// itself, deduplicating so a repeated signer can't be counted twice toward
+// quorum.
+func verifyCommitQC(qc *commitQC, validators [][]byte) error {
+	quorum := len(validators)*2/3 + 1
+
+	index := make(map[string]int, len(validators))
+	for i, v := range validators {
+		index[string(v)] = i
+	}
+
+	bitmap := make([]byte, (len(validators)+7)/8)
+	seen := make(map[string]bool, len(qc.Signers))
+	count := 0
+	for _, s := range qc.Signers {
+		if seen[string(s)] {
+			continue
+		}
+		idx, ok := index[string(s)]
+		if !ok {
+			return fmt.Errorf("commit QC signed by validator not in epoch set")
+		}
+		seen[string(s)] = true
+		bitmap[idx/8] |= 1 << uint(idx%8)
+		count++
+	}
+	if count < quorum {
+		return fmt.Errorf("commit QC has %d distinct signers, need quorum of %d", count, quorum)
+	}
+
+	aggQC := &aggregator.QC{Digest: qc.Digest, AggSig: qc.AggSig, SignerBitmap: bitmap}
+	if err := aggregator.Verify(aggQC, validators, quorum); err != nil {
+		return fmt.Errorf("commit QC aggregate signature invalid: %v", err)
+	}
+	return nil
+}
+
+func decodeCrossChainProof(raw []byte) (*crossChainProof, error) {
+	p := new(crossChainProof)
+	if err := rlp.DecodeBytes(raw, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// verifyMerkleProof checks proof.Proof is a valid Merkle-Patricia-Trie
+// proof for proof.Key against root (the header's CrossStatesRoot), and
+// that the proven value matches proof.Value. proof.Proof holds the raw
+// encoded trie nodes along the path, the same shape trie.Prove emits, so
+// they're loaded into a proof database keyed by node hash for
+// trie.VerifyProof to walk.
+func verifyMerkleProof(proof *crossChainProof, root common.Hash) error {
+	proofDB := memorydb.New()
+	for _, node := range proof.Proof {
+		if err := proofDB.Put(crypto.Keccak256(node), node); err != nil {
+			return err
+		}
+	}
+
+	value, err := trie.VerifyProof(root, proof.Key, proofDB)
+	if err != nil {
+		return fmt.Errorf("merkle proof verification failed: %v", err)
+	}
+	if !bytes.Equal(value, proof.Value) {
+		return fmt.Errorf("merkle proof proved a different value than expected")
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package hotstuff
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// buildProof inserts key/value into a fresh trie and returns its root
+// together with a crossChainProof a relayer would submit for that key.
+func buildProof(t *testing.T, key, value []byte) (common.Hash, *crossChainProof) {
+	t.Helper()
+
+	tr, err := trie.New(common.Hash{}, trie.NewDatabase(memorydb.New()))
+	if err != nil {
+		t.Fatalf("new trie: %v", err)
+	}
+	if err := tr.TryUpdate(key, value); err != nil {
+		t.Fatalf("update trie: %v", err)
+	}
+	root := tr.Hash()
+
+	proofDB := memorydb.New()
+	if err := tr.Prove(key, 0, proofDB); err != nil {
+		t.Fatalf("prove: %v", err)
+	}
+
+	it := proofDB.NewIterator(nil, nil)
+	defer it.Release()
+	var nodes [][]byte
+	for it.Next() {
+		node := make([]byte, len(it.Value()))
+		copy(node, it.Value())
+		nodes = append(nodes, node)
+	}
+
+	return root, &crossChainProof{Key: key, Value: value, Proof: nodes}
+}
+
+func TestVerifyMerkleProofAccepts(t *testing.T) {
+	root, proof := buildProof(t, []byte("cross-chain-key"), []byte("cross-chain-value"))
+	if err := verifyMerkleProof(proof, root); err != nil {
+		t.Fatalf("expected valid proof to verify, got error: %v", err)
+	}
+}
+
+func TestVerifyMerkleProofRejectsWrongValue(t *testing.T) {
+	root, proof := buildProof(t, []byte("cross-chain-key"), []byte("cross-chain-value"))
+	proof.Value = []byte("tampered-value")
+	if err := verifyMerkleProof(proof, root); err == nil {
+		t.Fatalf("expected tampered value to fail verification")
+	}
+}
+
+func TestVerifyMerkleProofRejectsWrongRoot(t *testing.T) {
+	_, proof := buildProof(t, []byte("cross-chain-key"), []byte("cross-chain-value"))
+	otherRoot, _ := buildProof(t, []byte("other-key"), []byte("other-value"))
+	if err := verifyMerkleProof(proof, otherRoot); err == nil {
+		t.Fatalf("expected proof to fail against an unrelated root")
+	}
+}
+
+func TestVerifyMerkleProofRejectsMissingSiblings(t *testing.T) {
+	root, proof := buildProof(t, []byte("cross-chain-key"), []byte("cross-chain-value"))
+	if len(proof.Proof) == 0 {
+		t.Skip("proof holds no nodes to drop")
+	}
+	proof.Proof = proof.Proof[:len(proof.Proof)-1]
+	if bytes.Equal(proof.Key, nil) {
+		t.Fatalf("test setup error: empty key")
+	}
+	if err := verifyMerkleProof(proof, root); err == nil {
+		t.Fatalf("expected an incomplete proof path to fail verification")
+	}
+}